@@ -24,7 +24,10 @@
 // which imitate unix commands but for file system structures.
 //
 //	cat      Print files
+//	checksum Print recursive content-addressable digests
+//	diff     Compare two filesystem trees
 //	file     Determine files types
+//	find     Walk a filesystem tree filtering entries by predicate expressions
 //	hashsum  Print hashsums
 //	ls       List directory contents
 //	stat     Display file status
@@ -37,6 +40,7 @@ import (
 	"crypto/sha1" // #nosec
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
@@ -44,12 +48,15 @@ import (
 	"log"
 	"os"
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/xlab/treeprint"
 
 	"github.com/forensicanalysis/filetype"
+	"github.com/forensicanalysis/fscmd/contenthash"
 )
 
 const (
@@ -101,7 +108,27 @@ esac
 `
 )
 
+// FSCommand builds the fs root command around a single-filesystem parseFunc.
+// Every subcommand, including diff and find, resolves its arguments against
+// the one fs.FS returned by parseFunc; use FSCommandWithResolver to mix
+// multiple backends (e.g. "zip://" and "raw://" arguments) in one command
+// line instead.
 func FSCommand(parseFunc func(_ *cobra.Command, args []string) (fs.FS, []string, error)) *cobra.Command {
+	return FSCommandMulti(AdaptParseFunc(parseFunc), adaptParse2(parseFunc))
+}
+
+// FSCommandWithResolver builds the fs root command around an *FSResolver,
+// so "zip://", "raw://" and any other registered scheme can be mixed freely
+// across a command's arguments, with backends cached and reused by the
+// resolver across the whole command line.
+func FSCommandWithResolver(resolver *FSResolver) *cobra.Command {
+	return FSCommandMulti(resolver.Resolve, resolver.ResolveDiff)
+}
+
+// FSCommandMulti builds the fs root command around a MultiParseFunc and
+// Parse2Func, the shared entry point FSCommand and FSCommandWithResolver
+// wire their respective resolution strategies through.
+func FSCommandMulti(parse MultiParseFunc, parse2 Parse2Func) *cobra.Command {
 	var debug bool
 	rootCmd := &cobra.Command{Use: "fs", Short: "recursive file, filesystem and archive commands",
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
@@ -113,12 +140,35 @@ func FSCommand(parseFunc func(_ *cobra.Command, args []string) (fs.FS, []string,
 		BashCompletionFunction: bashCompletionFunc,
 	}
 
-	cat := &cobra.Command{Use: "cat", Short: "print files", Run: CatCmd(parseFunc)}
-	file := &cobra.Command{Use: "file", Short: "determine file type", Run: FileCmd(parseFunc)}
-	hashsum := &cobra.Command{Use: "hashsum", Short: "print hashsums", Run: HashsumCmd(parseFunc)}
-	ls := &cobra.Command{Use: "ls", Short: "list directory contents", Run: LsCmd(parseFunc)}
-	stat := &cobra.Command{Use: "stat", Short: "display file status", Run: StatCmd(parseFunc)}
-	tree := &cobra.Command{Use: "tree", Short: "list contents of directories in a tree-like format", Run: TreeCmd(parseFunc)}
+	singleParse := adaptMultiToSingle(parse)
+
+	cat := &cobra.Command{Use: "cat", Short: "print files", Run: CatCmd(parse)}
+	checksum := &cobra.Command{Use: "checksum", Short: "print recursive content-addressable digests", Run: ChecksumCmd(singleParse)}
+	diff := &cobra.Command{Use: "diff", Short: "compare two filesystem trees", Run: DiffCmd(parse2)}
+	file := &cobra.Command{Use: "file", Short: "determine file type", Run: FileCmd(singleParse)}
+	find := &cobra.Command{
+		Use:                "find",
+		Short:              "walk a filesystem tree filtering entries by predicate expressions",
+		Run:                FindCmd(parse),
+		DisableFlagParsing: true,
+	}
+	hashsum := &cobra.Command{Use: "hashsum", Short: "print hashsums", Run: HashsumCmd(parse)}
+	ls := &cobra.Command{Use: "ls", Short: "list directory contents", Run: LsCmd(parse)}
+	stat := &cobra.Command{Use: "stat", Short: "display file status", Run: StatCmd(parse)}
+	strs := &cobra.Command{Use: "strings", Short: "find the printable strings in an object, or other binary, file", Run: StringsCmd(singleParse)}
+	tree := &cobra.Command{Use: "tree", Short: "list contents of directories in a tree-like format", Run: TreeCmd(parse)}
+
+	strs.Flags().IntP("bytes", "n", 4, "locate & print any sequence of at least [number] printable characters")
+	strs.Flags().StringP("encoding", "e", "s", "select character size and endianness: s = 7-bit, b = 16-bit bigendian, l = 16-bit littleendian")
+	strs.Flags().StringP("radix", "t", "", "print the location of the string in base 'd', 'o', or 'x'")
+
+	checksum.Flags().String("algo", "sha256", "hash algorithm to use (sha256, sha512)")
+	checksum.Flags().Bool("follow-symlinks", false, "hash symlink targets instead of the link itself")
+
+	diff.Flags().String("format", "text", "output format: text, json, ndjson")
+	diff.Flags().Bool("recursive", true, "compare directories recursively")
+	diff.Flags().Bool("names-only", false, "only compare names, not content")
+
 	complete := &cobra.Command{Use: "complete", Hidden: true, Run: func(cmd *cobra.Command, args []string) {
 		if err := rootCmd.GenBashCompletionFile(".bash_completion.sh"); err == nil {
 			log.Println("--")
@@ -133,9 +183,10 @@ func FSCommand(parseFunc func(_ *cobra.Command, args []string) (fs.FS, []string,
 	}}
 	/* go install . && fs complete && . /usr/local/etc/bash_completion && fs ls <tab> */
 
-	rootCmd.AddCommand(cat, file, hashsum, ls, stat, tree, complete)
+	rootCmd.AddCommand(cat, checksum, diff, file, find, hashsum, ls, stat, strs, tree, complete)
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "debug output")
 	_ = rootCmd.PersistentFlags().MarkHidden("debug")
+	rootCmd.PersistentFlags().String("output", "text", "output format: text, json, ndjson")
 	return rootCmd
 }
 
@@ -147,13 +198,105 @@ func exitOnError(err error) {
 	}
 }
 
-func CatCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func(_ *cobra.Command, args []string) {
+// FileEntry is the structured record emitted by ls and stat in json/ndjson
+// output mode.
+type FileEntry struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	IsDir   bool      `json:"isDir"`
+	ModTime time.Time `json:"modTime"`
+}
+
+func newFileEntry(name, p string, fi fs.FileInfo) FileEntry {
+	return FileEntry{Name: name, Path: p, Size: fi.Size(), Mode: fi.Mode().String(), IsDir: fi.IsDir(), ModTime: fi.ModTime()}
+}
+
+// fileTypeEntry is the structured record emitted by file in json/ndjson
+// output mode.
+type fileTypeEntry struct {
+	Path      string `json:"path"`
+	Mime      string `json:"mime"`
+	Extension string `json:"extension"`
+}
+
+// hashsumEntry is the structured record emitted by hashsum in json/ndjson
+// output mode.
+type hashsumEntry struct {
+	Path   string `json:"path"`
+	MD5    string `json:"md5"`
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+}
+
+// treeEntry is the structured, nested record emitted by tree in json/ndjson
+// output mode.
+type treeEntry struct {
+	Name     string      `json:"name"`
+	Children []treeEntry `json:"children,omitempty"`
+}
+
+// renderer emits records in the format selected by the command's --output
+// flag ("text", "json" or "ndjson"), falling back to plain os.Stdout text
+// when no cmd (and therefore no flag) is available, preserving the output
+// of callers written before --output existed.
+type renderer struct {
+	w       io.Writer
+	format  string
+	records []interface{}
+}
+
+func newRenderer(cmd *cobra.Command) *renderer {
+	format := "text"
+	var w io.Writer = os.Stdout
+	if cmd != nil {
+		if f, err := cmd.Flags().GetString("output"); err == nil && f != "" {
+			format = f
+		}
+		w = cmd.OutOrStdout()
+	}
+	return &renderer{w: w, format: format}
+}
+
+func (r *renderer) isText() bool { return r.format == "" || r.format == "text" }
+
+// emit records a structured record for json/ndjson output; it is a no-op in
+// text mode, where callers print their own human-readable lines instead.
+func (r *renderer) emit(record interface{}) error {
+	switch r.format {
+	case "json":
+		r.records = append(r.records, record)
+		return nil
+	case "ndjson":
+		return json.NewEncoder(r.w).Encode(record)
+	default:
+		return nil
+	}
+}
+
+// flush writes the buffered json array; it is a no-op for text and ndjson,
+// which are written incrementally by emit.
+func (r *renderer) flush() error {
+	if r.format != "json" {
+		return nil
+	}
+	b, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(r.w, string(b))
+	return err
+}
+
+func CatCmd(parse MultiParseFunc) func(_ *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
-		fsys, names, err := parse(cmd, args)
+		resources, err := parse(cmd, args)
 		exitOnError(err)
-		for _, name := range names {
+		for _, res := range resources {
 			func() {
-				r, err := fsys.Open(name)
+				r, err := res.FS.Open(res.Name)
 				exitOnError(err)
 				defer r.Close()
 
@@ -168,6 +311,7 @@ func FileCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func
 	return func(cmd *cobra.Command, args []string) {
 		fsys, names, err := parse(cmd, args)
 		exitOnError(err)
+		r := newRenderer(cmd)
 		b := make([]byte, 8192)
 		for _, name := range names {
 			func() {
@@ -176,99 +320,150 @@ func FileCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func
 				defer f.Close()
 				_, err = f.Read(b)
 				exitOnError(err)
-				fmt.Printf("%s: %s\n", name, filetype.Detect(b).Mimetype.Value)
+
+				ft := filetype.Detect(b)
+				if r.isText() {
+					fmt.Fprintf(r.w, "%s: %s\n", name, ft.Mimetype.Value)
+					return
+				}
+				var ext string
+				if len(ft.Extensions) > 0 {
+					ext = ft.Extensions[0]
+				}
+				exitOnError(r.emit(fileTypeEntry{Path: name, Mime: ft.Mimetype.Value, Extension: ext}))
 			}()
 		}
+		exitOnError(r.flush())
 	}
 }
 
-func HashsumCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func(_ *cobra.Command, args []string) {
+func HashsumCmd(parse MultiParseFunc) func(_ *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
-		fsys, names, err := parse(cmd, args)
+		resources, err := parse(cmd, args)
 		exitOnError(err)
-		for _, name := range names {
+		r := newRenderer(cmd)
+		for _, res := range resources {
 			md5hash := md5.New()   // #nosec
 			sha1hash := sha1.New() // #nosec
 			sha256hash := sha256.New()
 			sha512hash := sha512.New()
 			hash := io.MultiWriter(md5hash, sha1hash, sha256hash, sha512hash)
 
-			r, err := fsys.Open(name)
+			f, err := res.FS.Open(res.Name)
 			exitOnError(err)
-			_, err = io.Copy(hash, r)
+			_, err = io.Copy(hash, f)
 			exitOnError(err)
-			exitOnError(r.Close())
-			fmt.Printf("MD5: %x\n", md5hash.Sum(nil))
-			fmt.Printf("SHA1: %x\n", sha1hash.Sum(nil))
-			fmt.Printf("SHA256: %x\n", sha256hash.Sum(nil))
-			fmt.Printf("SHA512: %x\n", sha512hash.Sum(nil))
+			exitOnError(f.Close())
+
+			if r.isText() {
+				fmt.Fprintf(r.w, "MD5: %x\n", md5hash.Sum(nil))
+				fmt.Fprintf(r.w, "SHA1: %x\n", sha1hash.Sum(nil))
+				fmt.Fprintf(r.w, "SHA256: %x\n", sha256hash.Sum(nil))
+				fmt.Fprintf(r.w, "SHA512: %x\n", sha512hash.Sum(nil))
+				continue
+			}
+			exitOnError(r.emit(hashsumEntry{
+				Path:   res.Name,
+				MD5:    fmt.Sprintf("%x", md5hash.Sum(nil)),
+				SHA1:   fmt.Sprintf("%x", sha1hash.Sum(nil)),
+				SHA256: fmt.Sprintf("%x", sha256hash.Sum(nil)),
+				SHA512: fmt.Sprintf("%x", sha512hash.Sum(nil)),
+			}))
 		}
+		exitOnError(r.flush())
 	}
 }
 
-func LsCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func(_ *cobra.Command, args []string) {
+func LsCmd(parse MultiParseFunc) func(_ *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
-		fsys, names, err := parse(cmd, args)
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		resources, err := parse(cmd, args)
 		exitOnError(err)
 
-		if len(names) == 0 {
-			names = []string{"."}
-		}
-		for _, name := range names {
-			fi, err := fs.Stat(fsys, name)
+		r := newRenderer(cmd)
+		for _, res := range resources {
+			fi, err := fs.Stat(res.FS, res.Name)
 			exitOnError(err)
 			if fi.IsDir() {
-				entries, err := fs.ReadDir(fsys, name)
+				entries, err := fs.ReadDir(res.FS, res.Name)
 				exitOnError(err)
 
 				for _, entry := range entries {
-					child, err := fs.Stat(fsys, path.Join(name, entry.Name()))
+					childPath := path.Join(res.Name, entry.Name())
+					child, err := fs.Stat(res.FS, childPath)
 					if err != nil {
-						fmt.Println(entry, err)
+						if r.isText() {
+							fmt.Fprintln(r.w, entry, err)
+						}
 						continue
 					}
-					if child.IsDir() {
-						fmt.Println(entry.Name() + "/")
-					} else {
-						fmt.Println(entry.Name())
+					if r.isText() {
+						if child.IsDir() {
+							fmt.Fprintln(r.w, entry.Name()+"/")
+						} else {
+							fmt.Fprintln(r.w, entry.Name())
+						}
+						continue
 					}
+					exitOnError(r.emit(newFileEntry(entry.Name(), childPath, child)))
 				}
+			} else if r.isText() {
+				fmt.Fprintln(r.w, res.Name)
 			} else {
-				fmt.Println(name)
+				exitOnError(r.emit(newFileEntry(fi.Name(), res.Name, fi)))
 			}
 		}
+		exitOnError(r.flush())
 	}
 }
 
-func StatCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func(_ *cobra.Command, args []string) {
+func StatCmd(parse MultiParseFunc) func(_ *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
-		fsys, names, err := parse(cmd, args)
+		resources, err := parse(cmd, args)
 		exitOnError(err)
-		for _, name := range names {
-			fi, err := fs.Stat(fsys, name)
+		r := newRenderer(cmd)
+		for _, res := range resources {
+			fi, err := fs.Stat(res.FS, res.Name)
 			exitOnError(err)
-			fmt.Printf("Name: %v\n", fi.Name())
-			fmt.Printf("Size: %v\n", fi.Size())
-			fmt.Printf("IsDir: %v\n", fi.IsDir())
-			fmt.Printf("Mode: %s\n", fi.Mode())
-			fmt.Printf("Modified: %s\n", fi.ModTime())
+			if r.isText() {
+				fmt.Fprintf(r.w, "Name: %v\n", fi.Name())
+				fmt.Fprintf(r.w, "Size: %v\n", fi.Size())
+				fmt.Fprintf(r.w, "IsDir: %v\n", fi.IsDir())
+				fmt.Fprintf(r.w, "Mode: %s\n", fi.Mode())
+				fmt.Fprintf(r.w, "Modified: %s\n", fi.ModTime())
+				continue
+			}
+			exitOnError(r.emit(newFileEntry(fi.Name(), res.Name, fi)))
 		}
+		exitOnError(r.flush())
 	}
 }
 
-func TreeCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func(_ *cobra.Command, args []string) {
+func TreeCmd(parse MultiParseFunc) func(_ *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
-		fsys, names, err := parse(cmd, args)
-		exitOnError(err)
-		if len(names) == 0 {
-			names = []string{"."}
+		if len(args) == 0 {
+			args = []string{"."}
 		}
-		for _, name := range names {
-			tree := treeprint.New()
-			tree.SetValue(name)
-			children(fsys, tree, name)
-			fmt.Println(strings.TrimSpace(tree.String()))
+		resources, err := parse(cmd, args)
+		exitOnError(err)
+
+		r := newRenderer(cmd)
+		for _, res := range resources {
+			if r.isText() {
+				tree := treeprint.New()
+				tree.SetValue(res.Name)
+				children(res.FS, tree, res.Name)
+				fmt.Fprintln(r.w, strings.TrimSpace(tree.String()))
+				continue
+			}
+
+			entry := treeEntry{Name: res.Name}
+			buildTreeEntry(res.FS, &entry, res.Name)
+			exitOnError(r.emit(entry))
 		}
+		exitOnError(r.flush())
 	}
 }
 
@@ -285,3 +480,371 @@ func children(fsys fs.FS, tree treeprint.Tree, name string) {
 		}
 	}
 }
+
+func buildTreeEntry(fsys fs.FS, entry *treeEntry, name string) {
+	fi, err := fs.Stat(fsys, name)
+	exitOnError(err)
+	if fi.IsDir() {
+		dirEntries, err := fs.ReadDir(fsys, name)
+		exitOnError(err)
+
+		for _, dirEntry := range dirEntries {
+			child := treeEntry{Name: dirEntry.Name()}
+			buildTreeEntry(fsys, &child, path.Join(name, dirEntry.Name()))
+			entry.Children = append(entry.Children, child)
+		}
+	}
+}
+
+func ChecksumCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func(_ *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		fsys, names, err := parse(cmd, args)
+		exitOnError(err)
+
+		algoFlag, err := cmd.Flags().GetString("algo")
+		exitOnError(err)
+		algo, err := contenthash.ParseAlgorithm(algoFlag)
+		exitOnError(err)
+		followSymlinks, err := cmd.Flags().GetBool("follow-symlinks")
+		exitOnError(err)
+
+		checksummer := contenthash.New(fsys, algo, followSymlinks)
+		for _, name := range names {
+			digest, err := checksummer.Checksum(name)
+			exitOnError(err)
+			fmt.Printf("%s  %s\n", digest, name)
+		}
+	}
+}
+
+// Parse2Func resolves the two URL-style arguments of a comparison command
+// (e.g. diff) into two independently backed filesystems, one per side.
+type Parse2Func func(cmd *cobra.Command, args []string) (fsysA fs.FS, nameA string, fsysB fs.FS, nameB string, err error)
+
+// adaptParse2 builds a Parse2Func from a regular single-filesystem parseFunc
+// by resolving each of the two diff arguments on its own, so callers that
+// only implement the single-FS parseFunc still work with DiffCmd.
+func adaptParse2(parse func(*cobra.Command, []string) (fs.FS, []string, error)) Parse2Func {
+	return func(cmd *cobra.Command, args []string) (fs.FS, string, fs.FS, string, error) {
+		if len(args) != 2 {
+			return nil, "", nil, "", fmt.Errorf("diff requires exactly two paths, got %d", len(args))
+		}
+
+		fsysA, namesA, err := parse(cmd, args[0:1])
+		if err != nil {
+			return nil, "", nil, "", err
+		}
+		fsysB, namesB, err := parse(cmd, args[1:2])
+		if err != nil {
+			return nil, "", nil, "", err
+		}
+		return fsysA, namesA[0], fsysB, namesB[0], nil
+	}
+}
+
+// diffEntry describes a single change between two filesystem trees.
+type diffEntry struct {
+	Op      string `json:"op"`
+	Path    string `json:"path"`
+	OldPath string `json:"oldPath,omitempty"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	SHA256  string `json:"sha256"`
+}
+
+// treeFile holds the metadata collected for a single file while walking a
+// tree for diffing.
+type treeFile struct {
+	size   int64
+	mode   fs.FileMode
+	sha256 string
+}
+
+func DiffCmd(parse Parse2Func) func(_ *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		fsysA, rootA, fsysB, rootB, err := parse(cmd, args)
+		exitOnError(err)
+
+		format, err := cmd.Flags().GetString("format")
+		exitOnError(err)
+		recursive, err := cmd.Flags().GetBool("recursive")
+		exitOnError(err)
+		namesOnly, err := cmd.Flags().GetBool("names-only")
+		exitOnError(err)
+
+		filesA, err := walkFiles(fsysA, rootA, recursive)
+		exitOnError(err)
+		filesB, err := walkFiles(fsysB, rootB, recursive)
+		exitOnError(err)
+
+		diffs := diffFiles(filesA, filesB, namesOnly)
+		exitOnError(printDiff(diffs, format))
+	}
+}
+
+// walkFiles collects the regular files below root in fsys, keyed by their
+// path relative to root. It is the shared tree-walking logic behind diff,
+// generalized from the children() walker used by tree.
+func walkFiles(fsys fs.FS, root string, recursive bool) (map[string]treeFile, error) {
+	files := map[string]treeFile{}
+
+	fi, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		tf, err := hashFile(fsys, root, fi)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Base(root)] = tf
+		return files, nil
+	}
+
+	err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if d.IsDir() {
+			if !recursive {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		childInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		tf, err := hashFile(fsys, p, childInfo)
+		if err != nil {
+			return err
+		}
+		rel := p
+		if root != "." {
+			rel = strings.TrimPrefix(p, root+"/")
+		}
+		files[rel] = tf
+		return nil
+	})
+	return files, err
+}
+
+func hashFile(fsys fs.FS, name string, fi fs.FileInfo) (treeFile, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return treeFile{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return treeFile{}, err
+	}
+	return treeFile{size: fi.Size(), mode: fi.Mode(), sha256: fmt.Sprintf("%x", h.Sum(nil))}, nil
+}
+
+// diffFiles compares the two file sets and reports added, removed, modified
+// and renamed entries. Renames are detected by matching an added path and a
+// removed path that share the same content hash.
+func diffFiles(a, b map[string]treeFile, namesOnly bool) []diffEntry {
+	var added, removed []string
+	for p := range b {
+		if _, ok := a[p]; !ok {
+			added = append(added, p)
+		}
+	}
+	for p := range a {
+		if _, ok := b[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	renamedTo := map[string]bool{}
+	renamedFrom := map[string]bool{}
+
+	var diffs []diffEntry
+	if !namesOnly {
+		for _, oldPath := range removed {
+			for _, newPath := range added {
+				if renamedTo[newPath] {
+					continue
+				}
+				if a[oldPath].sha256 == b[newPath].sha256 {
+					diffs = append(diffs, diffEntry{
+						Op: "rename", Path: newPath, OldPath: oldPath,
+						Size: b[newPath].size, Mode: b[newPath].mode.String(), SHA256: b[newPath].sha256,
+					})
+					renamedTo[newPath] = true
+					renamedFrom[oldPath] = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, p := range added {
+		if renamedTo[p] {
+			continue
+		}
+		diffs = append(diffs, diffEntry{Op: "add", Path: p, Size: b[p].size, Mode: b[p].mode.String(), SHA256: b[p].sha256})
+	}
+	for _, p := range removed {
+		if renamedFrom[p] {
+			continue
+		}
+		diffs = append(diffs, diffEntry{Op: "remove", Path: p, Size: a[p].size, Mode: a[p].mode.String(), SHA256: a[p].sha256})
+	}
+
+	if !namesOnly {
+		var common []string
+		for p := range a {
+			if _, ok := b[p]; ok {
+				common = append(common, p)
+			}
+		}
+		sort.Strings(common)
+		for _, p := range common {
+			if a[p].sha256 != b[p].sha256 {
+				diffs = append(diffs, diffEntry{Op: "modify", Path: p, Size: b[p].size, Mode: b[p].mode.String(), SHA256: b[p].sha256})
+			}
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func printDiff(diffs []diffEntry, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	case "ndjson":
+		enc := json.NewEncoder(os.Stdout)
+		for _, d := range diffs {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, d := range diffs {
+			switch d.Op {
+			case "rename":
+				fmt.Printf("R %s -> %s\n", d.OldPath, d.Path)
+			case "add":
+				fmt.Printf("A %s\n", d.Path)
+			case "remove":
+				fmt.Printf("D %s\n", d.Path)
+			case "modify":
+				fmt.Printf("M %s\n", d.Path)
+			}
+		}
+	}
+	return nil
+}
+
+func StringsCmd(parse func(*cobra.Command, []string) (fs.FS, []string, error)) func(_ *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		fsys, names, err := parse(cmd, args)
+		exitOnError(err)
+
+		minLen, err := cmd.Flags().GetInt("bytes")
+		exitOnError(err)
+		encoding, err := cmd.Flags().GetString("encoding")
+		exitOnError(err)
+		radix, err := cmd.Flags().GetString("radix")
+		exitOnError(err)
+
+		for _, name := range names {
+			func() {
+				f, err := fsys.Open(name)
+				exitOnError(err)
+				defer f.Close()
+
+				data, err := io.ReadAll(f)
+				exitOnError(err)
+
+				for _, s := range findStrings(data, minLen, encoding) {
+					if radix != "" {
+						fmt.Printf("%s %s\n", formatOffset(s.offset, radix), s.text)
+					} else {
+						fmt.Println(s.text)
+					}
+				}
+			}()
+		}
+	}
+}
+
+type foundString struct {
+	offset int64
+	text   string
+}
+
+// findStrings scans data for runs of printable characters at least minLen long,
+// decoding either 7-bit ASCII ("s") or 16-bit little/big-endian Unicode ("l"/"b").
+func findStrings(data []byte, minLen int, encoding string) []foundString {
+	charSize := 1
+	if encoding == "l" || encoding == "b" {
+		charSize = 2
+	}
+
+	var results []foundString
+	var run []rune
+	var runStart int64
+
+	flush := func() {
+		if len(run) >= minLen {
+			results = append(results, foundString{offset: runStart, text: string(run)})
+		}
+		run = nil
+	}
+
+	for i := 0; i+charSize <= len(data); i += charSize {
+		var r rune
+		switch encoding {
+		case "l":
+			r = rune(uint16(data[i]) | uint16(data[i+1])<<8)
+		case "b":
+			r = rune(uint16(data[i])<<8 | uint16(data[i+1]))
+		default:
+			r = rune(data[i])
+		}
+
+		if isPrintableString(r) {
+			if len(run) == 0 {
+				runStart = int64(i)
+			}
+			run = append(run, r)
+		} else {
+			flush()
+		}
+	}
+	flush()
+
+	return results
+}
+
+func isPrintableString(r rune) bool {
+	return r >= 0x20 && r < 0x7f
+}
+
+func formatOffset(offset int64, radix string) string {
+	switch radix {
+	case "o":
+		return fmt.Sprintf("%o", offset)
+	case "x":
+		return fmt.Sprintf("%x", offset)
+	default:
+		return fmt.Sprintf("%d", offset)
+	}
+}
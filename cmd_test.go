@@ -24,6 +24,7 @@ package fscmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/spf13/cobra"
 	"io"
 	"io/fs"
@@ -64,6 +65,8 @@ func defaultParse(_ *cobra.Command, args []string) (fs.FS, []string, error) {
 	return testFS, args, nil
 }
 
+var defaultMultiParse = AdaptParseFunc(defaultParse)
+
 func Test_cat(t *testing.T) {
 	type args struct {
 		url string
@@ -77,7 +80,7 @@ func Test_cat(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotData := stdout(func() { CatCmd(defaultParse)(nil, []string{tt.args.url}) })
+			gotData := stdout(func() { CatCmd(defaultMultiParse)(nil, []string{tt.args.url}) })
 
 			re := regexp.MustCompile(`\r?\n`) // TODO: improve newline handling
 			gotDataString := re.ReplaceAllString(string(gotData), "")
@@ -109,7 +112,7 @@ func Test_ls(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotData := stdout(func() { LsCmd(defaultParse)(nil, []string{tt.args.url}) })
+			gotData := stdout(func() { LsCmd(defaultMultiParse)(nil, []string{tt.args.url}) })
 			if !reflect.DeepEqual(string(gotData), string(tt.wantData)) {
 				t.Errorf("ls() = %s, want %s", gotData, tt.wantData)
 				t.Errorf("ls() = %x, want %x", gotData, tt.wantData)
@@ -155,7 +158,7 @@ func Test_hashsum(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotData := stdout(func() { HashsumCmd(defaultParse)(nil, []string{tt.args.url}) })
+			gotData := stdout(func() { HashsumCmd(defaultMultiParse)(nil, []string{tt.args.url}) })
 			if !reflect.DeepEqual(string(gotData), string(tt.wantData)) {
 				t.Errorf("hashsum() = %s, want %s", gotData, tt.wantData)
 			}
@@ -182,7 +185,7 @@ Modified: 0001-01-01 00:00:00 +0000 UTC
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotData := stdout(func() { StatCmd(defaultParse)(nil, []string{tt.args.url}) })
+			gotData := stdout(func() { StatCmd(defaultMultiParse)(nil, []string{tt.args.url}) })
 			if !reflect.DeepEqual(string(gotData), string(tt.wantData)) {
 				t.Errorf("stat() = '%s', want '%s'", gotData, tt.wantData)
 			}
@@ -190,6 +193,108 @@ Modified: 0001-01-01 00:00:00 +0000 UTC
 	}
 }
 
+func Test_checksum(t *testing.T) {
+	cmd := &cobra.Command{Use: "checksum", Run: ChecksumCmd(defaultParse)}
+	cmd.Flags().String("algo", "sha256", "")
+	cmd.Flags().Bool("follow-symlinks", false, "")
+
+	gotData := stdout(func() { ChecksumCmd(defaultParse)(cmd, []string{"foo"}) })
+	want := "f5bda373b2e1626dcbf6557f273f10a4cee017ed2e4936178307fda8d0cf0181  foo\n"
+	if string(gotData) != want {
+		t.Errorf("checksum() = %s, want %s", gotData, want)
+	}
+
+	// repeated checksums for the same path must be stable
+	gotData2 := stdout(func() { ChecksumCmd(defaultParse)(cmd, []string{"foo"}) })
+	if string(gotData) != string(gotData2) {
+		t.Errorf("checksum() not stable: %s != %s", gotData, gotData2)
+	}
+}
+
+func Test_diff(t *testing.T) {
+	fsA := &fstest.MapFS{
+		"same":     &fstest.MapFile{Data: []byte("same")},
+		"removed":  &fstest.MapFile{Data: []byte("gone")},
+		"old-name": &fstest.MapFile{Data: []byte("moved")},
+		"changed":  &fstest.MapFile{Data: []byte("before")},
+	}
+	fsB := &fstest.MapFS{
+		"same":     &fstest.MapFile{Data: []byte("same")},
+		"added":    &fstest.MapFile{Data: []byte("new")},
+		"new-name": &fstest.MapFile{Data: []byte("moved")},
+		"changed":  &fstest.MapFile{Data: []byte("after")},
+	}
+
+	parse2 := func(_ *cobra.Command, args []string) (fs.FS, string, fs.FS, string, error) {
+		return fsA, args[0], fsB, args[1], nil
+	}
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "diff", Run: DiffCmd(parse2)}
+		cmd.Flags().String("format", "text", "")
+		cmd.Flags().Bool("recursive", true, "")
+		cmd.Flags().Bool("names-only", false, "")
+		return cmd
+	}
+
+	cmd := newCmd()
+	gotData := stdout(func() { DiffCmd(parse2)(cmd, []string{".", "."}) })
+	want := "A added\nM changed\nR old-name -> new-name\nD removed\n"
+	if string(gotData) != want {
+		t.Errorf("diff() = %q, want %q", gotData, want)
+	}
+}
+
+func Test_strings(t *testing.T) {
+	fsys := &fstest.MapFS{
+		"mixed": &fstest.MapFile{Data: []byte("\x00\x01hello\x00\x02world!\x00\x03")},
+		"utf16le": &fstest.MapFile{Data: []byte{
+			0, 0,
+			'h', 0, 'i', 0, '!', 0, '!', 0,
+			0, 0,
+		}},
+	}
+	parse := func(_ *cobra.Command, args []string) (fs.FS, []string, error) {
+		return fsys, args, nil
+	}
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "strings", Run: StringsCmd(parse)}
+		cmd.Flags().IntP("bytes", "n", 4, "")
+		cmd.Flags().StringP("encoding", "e", "s", "")
+		cmd.Flags().StringP("radix", "t", "", "")
+		return cmd
+	}
+
+	type args struct {
+		url   string
+		flags map[string]string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantData []byte
+	}{
+		{"strings", args{"mixed", nil}, []byte("hello\nworld!\n")},
+		{"strings min-len", args{"mixed", map[string]string{"bytes": "6"}}, []byte("world!\n")},
+		{"strings utf16le", args{"utf16le", map[string]string{"encoding": "l"}}, []byte("hi!!\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newCmd()
+			for name, value := range tt.args.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			gotData := stdout(func() { StringsCmd(parse)(cmd, []string{tt.args.url}) })
+			if !reflect.DeepEqual(string(gotData), string(tt.wantData)) {
+				t.Errorf("strings() = %s, want %s", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
 func Test_tree(t *testing.T) {
 	type args struct {
 		url string
@@ -203,7 +308,7 @@ func Test_tree(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotData := stdout(func() { TreeCmd(defaultParse)(nil, []string{tt.args.url}) })
+			gotData := stdout(func() { TreeCmd(defaultMultiParse)(nil, []string{tt.args.url}) })
 			if !reflect.DeepEqual(string(gotData), string(tt.wantData)) {
 				t.Errorf("tree() = '%s', want '%s'", gotData, tt.wantData)
 				t.Errorf("tree() = '%x', want '%x'", gotData, tt.wantData)
@@ -211,3 +316,106 @@ func Test_tree(t *testing.T) {
 		})
 	}
 }
+
+func Test_find(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantData []byte
+	}{
+		{"no expression", []string{"."}, []byte(".\nfolder\nfolder/bar\nfoo\n")},
+		{"name", []string{".", "-name", "foo"}, []byte("foo\n")},
+		{"type dir", []string{".", "-type", "d"}, []byte(".\nfolder\n")},
+		{"not", []string{".", "-type", "f", "-not", "-name", "foo"}, []byte("folder/bar\n")},
+		{"or", []string{".", "-name", "foo", "-or", "-name", "bar"}, []byte("folder/bar\nfoo\n")},
+		{"exec hash", []string{".", "-name", "foo", "-exec", "hash"},
+			[]byte("2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae  foo\n")},
+		{"regex matches whole path", []string{".", "-regex", "folder/bar"}, []byte("folder/bar\n")},
+		{"regex does not match substring", []string{".", "-regex", "ba"}, []byte("")},
+		{"iname", []string{".", "-iname", "FOO"}, []byte("foo\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotData := stdout(func() { FindCmd(defaultMultiParse)(nil, tt.args) })
+			if !reflect.DeepEqual(string(gotData), string(tt.wantData)) {
+				t.Errorf("find() = '%s', want '%s'", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+// cmdWithOutput builds a *cobra.Command carrying an --output flag set to
+// format, the way FSCommand wires it onto the real root command.
+func cmdWithOutput(format string) *cobra.Command {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("output", format, "")
+	return cmd
+}
+
+func Test_ls_json(t *testing.T) {
+	cmd := cmdWithOutput("json")
+	gotData := stdout(func() { LsCmd(defaultMultiParse)(cmd, []string{"."}) })
+
+	var entries []FileEntry
+	if err := json.Unmarshal(gotData, &entries); err != nil {
+		t.Fatalf("ls --output json produced invalid JSON: %s\n%s", err, gotData)
+	}
+	if len(entries) != 2 || entries[0].Name != "folder" || !entries[0].IsDir || entries[1].Name != "foo" {
+		t.Errorf("ls --output json = %+v, want folder (dir) then foo", entries)
+	}
+}
+
+func Test_stat_json(t *testing.T) {
+	cmd := cmdWithOutput("ndjson")
+	gotData := stdout(func() { StatCmd(defaultMultiParse)(cmd, []string{"foo"}) })
+
+	var entry FileEntry
+	if err := json.Unmarshal(gotData, &entry); err != nil {
+		t.Fatalf("stat --output ndjson produced invalid JSON: %s\n%s", err, gotData)
+	}
+	if entry.Name != "foo" || entry.Size != 3 || entry.IsDir {
+		t.Errorf("stat --output ndjson = %+v, want name=foo size=3 isDir=false", entry)
+	}
+}
+
+func Test_file_json(t *testing.T) {
+	cmd := cmdWithOutput("json")
+	gotData := stdout(func() { FileCmd(defaultParse)(cmd, []string{"foo"}) })
+
+	var entries []struct {
+		Path string `json:"path"`
+		Mime string `json:"mime"`
+	}
+	if err := json.Unmarshal(gotData, &entries); err != nil {
+		t.Fatalf("file --output json produced invalid JSON: %s\n%s", err, gotData)
+	}
+	if len(entries) != 1 || entries[0].Path != "foo" || entries[0].Mime != "text/plain" {
+		t.Errorf("file --output json = %+v, want path=foo mime=text/plain", entries)
+	}
+}
+
+func Test_hashsum_json(t *testing.T) {
+	cmd := cmdWithOutput("json")
+	gotData := stdout(func() { HashsumCmd(defaultMultiParse)(cmd, []string{"foo"}) })
+
+	var entries []hashsumEntry
+	if err := json.Unmarshal(gotData, &entries); err != nil {
+		t.Fatalf("hashsum --output json produced invalid JSON: %s\n%s", err, gotData)
+	}
+	if len(entries) != 1 || entries[0].SHA256 != "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae" {
+		t.Errorf("hashsum --output json = %+v, want sha256 of foo", entries)
+	}
+}
+
+func Test_tree_json(t *testing.T) {
+	cmd := cmdWithOutput("json")
+	gotData := stdout(func() { TreeCmd(defaultMultiParse)(cmd, []string{"."}) })
+
+	var entries []treeEntry
+	if err := json.Unmarshal(gotData, &entries); err != nil {
+		t.Fatalf("tree --output json produced invalid JSON: %s\n%s", err, gotData)
+	}
+	if len(entries) != 1 || entries[0].Name != "." || len(entries[0].Children) != 2 {
+		t.Errorf("tree --output json = %+v, want root '.' with 2 children", entries)
+	}
+}
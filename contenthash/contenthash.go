@@ -0,0 +1,215 @@
+// Copyright (c) 2019-2020 Siemens AG
+// Copyright (c) 2019-2021 Jonas Plum
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package contenthash computes recursive, content-addressable digests for
+// paths inside an fs.FS, following the directory-Merkle construction used by
+// buildkit's contenthash package. It lets callers compare two filesystem
+// images (or two points in one image) by a single hash per path.
+package contenthash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+
+	iradix "github.com/hashicorp/go-immutable-radix"
+)
+
+// Algorithm selects the hash function used to build digests.
+type Algorithm string
+
+// Supported algorithms.
+const (
+	SHA256 Algorithm = "sha256"
+	SHA512 Algorithm = "sha512"
+)
+
+// ParseAlgorithm validates a CLI-supplied algorithm name.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	switch Algorithm(s) {
+	case SHA256:
+		return SHA256, nil
+	case SHA512:
+		return SHA512, nil
+	default:
+		return "", fmt.Errorf("unsupported algorithm %q", s)
+	}
+}
+
+func (a Algorithm) new() hash.Hash {
+	switch a {
+	case SHA512:
+		return sha512.New()
+	default:
+		return sha256.New()
+	}
+}
+
+// ReadLinkFS is implemented by fs.FS backends that support symlinks. When the
+// wrapped fs.FS does not implement it, symlinks are hashed with an empty
+// target.
+type ReadLinkFS interface {
+	ReadLink(name string) (string, error)
+}
+
+// Checksummer computes recursive digests for paths in fsys, caching header
+// and content digests of visited paths in an immutable radix tree so that
+// overlapping subtree queries (e.g. repeated calls for a/b/c and a/b) reuse
+// prior work.
+type Checksummer struct {
+	fsys           fs.FS
+	algo           Algorithm
+	followSymlinks bool
+	tree           *iradix.Tree
+}
+
+// New creates a Checksummer over fsys using algo. When followSymlinks is
+// true, symlinks are hashed by the content of their target instead of the
+// target path itself.
+func New(fsys fs.FS, algo Algorithm, followSymlinks bool) *Checksummer {
+	return &Checksummer{
+		fsys:           fsys,
+		algo:           algo,
+		followSymlinks: followSymlinks,
+		tree:           iradix.New(),
+	}
+}
+
+// Checksum returns the hex-encoded recursive digest of name.
+func (c *Checksummer) Checksum(name string) (string, error) {
+	digest, err := c.digest(path.Clean(name))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", digest), nil
+}
+
+func (c *Checksummer) digest(name string) ([]byte, error) {
+	if cached, ok := c.tree.Get(contentKey(name)); ok {
+		return cached.([]byte), nil
+	}
+
+	fi, err := fs.Stat(c.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := c.headerDigest(name, fi)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := c.contentDigest(name, fi)
+	if err != nil {
+		return nil, err
+	}
+
+	final := c.algo.new()
+	final.Write(header)
+	final.Write(content)
+	digest := final.Sum(nil)
+
+	c.tree, _, _ = c.tree.Insert(contentKey(name), digest)
+	return digest, nil
+}
+
+// headerDigest hashes the entry's name, mode, size and (if a symlink) link
+// target, independent of its content.
+func (c *Checksummer) headerDigest(name string, fi fs.FileInfo) ([]byte, error) {
+	if cached, ok := c.tree.Get(headerKey(name)); ok {
+		return cached.([]byte), nil
+	}
+
+	h := c.algo.new()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00", path.Base(name), fi.Mode(), fi.Size())
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		target, err := c.readLink(name)
+		if err != nil {
+			return nil, err
+		}
+		io.WriteString(h, target) // nolint:errcheck
+	}
+	digest := h.Sum(nil)
+
+	c.tree, _, _ = c.tree.Insert(headerKey(name), digest)
+	return digest, nil
+}
+
+// contentDigest hashes the entry's content: file bytes for regular files,
+// the symlink target (or its content, if followSymlinks is set) for
+// symlinks, and the ordered concatenation of child digests for directories.
+func (c *Checksummer) contentDigest(name string, fi fs.FileInfo) ([]byte, error) {
+	switch {
+	case fi.IsDir():
+		entries, err := fs.ReadDir(c.fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+		var children bytes.Buffer
+		for _, entry := range entries {
+			child, err := c.digest(path.Join(name, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			children.Write(child)
+		}
+		h := c.algo.new()
+		h.Write(children.Bytes())
+		return h.Sum(nil), nil
+	case fi.Mode()&fs.ModeSymlink != 0 && !c.followSymlinks:
+		target, err := c.readLink(name)
+		if err != nil {
+			return nil, err
+		}
+		h := c.algo.new()
+		io.WriteString(h, target) // nolint:errcheck
+		return h.Sum(nil), nil
+	default:
+		f, err := c.fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		h := c.algo.new()
+		if _, err := io.Copy(h, f); err != nil {
+			return nil, err
+		}
+		return h.Sum(nil), nil
+	}
+}
+
+func (c *Checksummer) readLink(name string) (string, error) {
+	if rl, ok := c.fsys.(ReadLinkFS); ok {
+		return rl.ReadLink(name)
+	}
+	return "", nil
+}
+
+func headerKey(name string) []byte  { return []byte("h:" + name) }
+func contentKey(name string) []byte { return []byte("c:" + name) }
@@ -0,0 +1,90 @@
+// Copyright (c) 2019-2020 Siemens AG
+// Copyright (c) 2019-2021 Jonas Plum
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package contenthash
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"foo":        &fstest.MapFile{Data: []byte("foo")},
+	"folder/bar": &fstest.MapFile{Data: []byte("bar")},
+}
+
+func Test_Checksum(t *testing.T) {
+	c := New(testFS, SHA256, false)
+
+	foo, err := c.Checksum("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(foo) != 64 {
+		t.Errorf("Checksum() = %s, want 64 hex chars", foo)
+	}
+
+	// repeated calls for the same path must be stable
+	again, err := c.Checksum("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foo != again {
+		t.Errorf("Checksum() not stable: %s != %s", foo, again)
+	}
+
+	root, err := c.Checksum(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root == foo {
+		t.Errorf("Checksum(.) should differ from Checksum(foo)")
+	}
+}
+
+func Test_Checksum_algo(t *testing.T) {
+	c256 := New(testFS, SHA256, false)
+	c512 := New(testFS, SHA512, false)
+
+	foo256, err := c256.Checksum("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	foo512, err := c512.Checksum("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(foo256) != 64 {
+		t.Errorf("sha256 Checksum() = %s, want 64 hex chars", foo256)
+	}
+	if len(foo512) != 128 {
+		t.Errorf("sha512 Checksum() = %s, want 128 hex chars", foo512)
+	}
+}
+
+func Test_ParseAlgorithm(t *testing.T) {
+	if _, err := ParseAlgorithm("sha256"); err != nil {
+		t.Errorf("ParseAlgorithm(sha256) returned error: %s", err)
+	}
+	if _, err := ParseAlgorithm("md5"); err == nil {
+		t.Errorf("ParseAlgorithm(md5) should have returned an error")
+	}
+}
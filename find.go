@@ -0,0 +1,496 @@
+// Copyright (c) 2019-2020 Siemens AG
+// Copyright (c) 2019-2021 Jonas Plum
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package fscmd
+
+import (
+	"crypto/md5"  // #nosec
+	"crypto/sha1" // #nosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forensicanalysis/filetype"
+)
+
+// findEntry carries the information predicates need about a single entry
+// encountered while walking an fs.FS.
+type findEntry struct {
+	fsys fs.FS
+	path string
+	fi   fs.FileInfo
+}
+
+// findExpr is a node in the predicate expression tree built from the -name,
+// -type, -and, -or, -not, ... tokens following the find roots.
+type findExpr interface {
+	eval(e findEntry) (bool, error)
+}
+
+type andExpr struct{ a, b findExpr }
+
+func (x andExpr) eval(e findEntry) (bool, error) {
+	ok, err := x.a.eval(e)
+	if err != nil || !ok {
+		return false, err
+	}
+	return x.b.eval(e)
+}
+
+type orExpr struct{ a, b findExpr }
+
+func (x orExpr) eval(e findEntry) (bool, error) {
+	ok, err := x.a.eval(e)
+	if err != nil || ok {
+		return ok, err
+	}
+	return x.b.eval(e)
+}
+
+type notExpr struct{ x findExpr }
+
+func (n notExpr) eval(e findEntry) (bool, error) {
+	ok, err := n.x.eval(e)
+	return !ok, err
+}
+
+// predicate is a leaf findExpr built directly from a single -flag token.
+type predicate func(e findEntry) (bool, error)
+
+func (p predicate) eval(e findEntry) (bool, error) { return p(e) }
+
+// findParser turns the expression tokens following the find roots into a
+// findExpr, in the same precedence as GNU find: -not binds tightest,
+// adjacent predicates are implicitly -and'ed, and -or has the lowest
+// precedence. -exec is parsed as an always-true predicate that additionally
+// records the action to run on every match.
+type findParser struct {
+	tokens []string
+	pos    int
+	action string
+}
+
+func (p *findParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *findParser) next() (string, bool) {
+	tok, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return tok, ok
+}
+
+func (p *findParser) arg(forTok string) (string, error) {
+	v, ok := p.next()
+	if !ok {
+		return "", fmt.Errorf("fscmd: %s requires an argument", forTok)
+	}
+	return v, nil
+}
+
+func (p *findParser) parseExpr() (findExpr, error) { return p.parseOr() }
+
+func (p *findParser) parseOr() (findExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok != "-or" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+}
+
+func (p *findParser) parseAnd() (findExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok == ")" || tok == "-or" {
+			return left, nil
+		}
+		if tok == "-and" {
+			p.next()
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+}
+
+func (p *findParser) parseNot() (findExpr, error) {
+	tok, ok := p.peek()
+	if ok && tok == "-not" {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *findParser) parsePrimary() (findExpr, error) {
+	tok, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("fscmd: unexpected end of expression")
+	}
+
+	switch tok {
+	case "(":
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.next(); !ok || closing != ")" {
+			return nil, fmt.Errorf("fscmd: expected ')'")
+		}
+		return x, nil
+
+	case "-name", "-iname":
+		glob, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		caseFold := tok == "-iname"
+		if caseFold {
+			glob = strings.ToLower(glob)
+		}
+		return predicate(func(e findEntry) (bool, error) {
+			name := path.Base(e.path)
+			if caseFold {
+				name = strings.ToLower(name)
+			}
+			return path.Match(glob, name)
+		}), nil
+
+	case "-regex":
+		re, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		rx, err := regexp.Compile("^(?:" + re + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("fscmd: invalid -regex %q: %w", re, err)
+		}
+		return predicate(func(e findEntry) (bool, error) { return rx.MatchString(e.path), nil }), nil
+
+	case "-size":
+		spec, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		cmp, want, err := parseSizeSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		return predicate(func(e findEntry) (bool, error) { return cmp(e.fi.Size(), want), nil }), nil
+
+	case "-type":
+		typ, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		return predicate(func(e findEntry) (bool, error) { return matchType(typ, e.fi) }), nil
+
+	case "-mime":
+		re, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		rx, err := regexp.Compile(re)
+		if err != nil {
+			return nil, fmt.Errorf("fscmd: invalid -mime %q: %w", re, err)
+		}
+		return predicate(func(e findEntry) (bool, error) {
+			if e.fi.IsDir() {
+				return false, nil
+			}
+			mime, err := detectMime(e.fsys, e.path)
+			if err != nil {
+				return false, err
+			}
+			return rx.MatchString(mime), nil
+		}), nil
+
+	case "-newer":
+		refPath, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		return predicate(func(e findEntry) (bool, error) {
+			ref, err := fs.Stat(e.fsys, refPath)
+			if err != nil {
+				return false, err
+			}
+			return e.fi.ModTime().After(ref.ModTime()), nil
+		}), nil
+
+	case "-hash":
+		spec, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		algo, want, err := parseHashSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		return predicate(func(e findEntry) (bool, error) {
+			if e.fi.IsDir() {
+				return false, nil
+			}
+			got, err := hashFileContent(e.fsys, e.path, algo)
+			if err != nil {
+				return false, err
+			}
+			return strings.EqualFold(got, want), nil
+		}), nil
+
+	case "-exec":
+		action, err := p.arg(tok)
+		if err != nil {
+			return nil, err
+		}
+		switch action {
+		case "print", "hash", "stat", "cat":
+		default:
+			return nil, fmt.Errorf("fscmd: unknown -exec action %q", action)
+		}
+		p.action = action
+		return predicate(func(findEntry) (bool, error) { return true, nil }), nil
+
+	default:
+		return nil, fmt.Errorf("fscmd: unknown predicate %q", tok)
+	}
+}
+
+// parseSizeSpec parses a find-style "[+-]N[ckMG]" size spec into a
+// comparison function and the comparison value in bytes. A "+" prefix means
+// greater than, "-" means less than, and no prefix means an exact match.
+// Suffixes select the unit: c = bytes (the default), k = KiB, M = MiB, G =
+// GiB.
+func parseSizeSpec(spec string) (func(actual, want int64) bool, int64, error) {
+	cmp := func(a, b int64) bool { return a == b }
+	rest := spec
+	switch {
+	case strings.HasPrefix(spec, "+"):
+		cmp = func(a, b int64) bool { return a > b }
+		rest = spec[1:]
+	case strings.HasPrefix(spec, "-"):
+		cmp = func(a, b int64) bool { return a < b }
+		rest = spec[1:]
+	}
+
+	mult := int64(1)
+	if n := len(rest); n > 0 {
+		switch rest[n-1] {
+		case 'c':
+			rest = rest[:n-1]
+		case 'k':
+			mult, rest = 1024, rest[:n-1]
+		case 'M':
+			mult, rest = 1024*1024, rest[:n-1]
+		case 'G':
+			mult, rest = 1024*1024*1024, rest[:n-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fscmd: invalid -size %q: %w", spec, err)
+	}
+	return cmp, n * mult, nil
+}
+
+func matchType(typ string, fi fs.FileInfo) (bool, error) {
+	switch typ {
+	case "f":
+		return fi.Mode().IsRegular(), nil
+	case "d":
+		return fi.IsDir(), nil
+	case "l":
+		return fi.Mode()&fs.ModeSymlink != 0, nil
+	default:
+		return false, fmt.Errorf("fscmd: unknown -type %q", typ)
+	}
+}
+
+func detectMime(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	b := make([]byte, 8192)
+	n, err := f.Read(b)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return filetype.Detect(b[:n]).Mimetype.Value, nil
+}
+
+// parseHashSpec splits a "sha256:HEX"-style -hash argument.
+func parseHashSpec(spec string) (algo, hexDigest string, err error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("fscmd: -hash expects algo:hex, got %q", spec)
+	}
+	return parts[0], parts[1], nil
+}
+
+func hashFileContent(fsys fs.FS, name, algo string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var h hash.Hash
+	switch algo {
+	case "md5":
+		h = md5.New() // #nosec
+	case "sha1":
+		h = sha1.New() // #nosec
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("fscmd: unknown hash algorithm %q", algo)
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// FindCmd walks the given roots (or "." if none are given) and, for every
+// entry matching the predicate expression built from the remaining
+// arguments, runs the -exec action (print by default). Like diff's
+// walkFiles, it walks each root with fs.WalkDir.
+func FindCmd(parse MultiParseFunc) func(_ *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		i := 0
+		for i < len(args) && args[i] != "(" && !strings.HasPrefix(args[i], "-") {
+			i++
+		}
+		roots, tokens := args[:i], args[i:]
+		if len(roots) == 0 {
+			roots = []string{"."}
+		}
+
+		p := &findParser{tokens: tokens, action: "print"}
+		expr, err := p.parseExpr()
+		if len(tokens) == 0 {
+			expr, err = predicate(func(findEntry) (bool, error) { return true, nil }), nil
+		}
+		exitOnError(err)
+
+		resources, err := parse(cmd, roots)
+		exitOnError(err)
+
+		for _, res := range resources {
+			exitOnError(fs.WalkDir(res.FS, res.Name, func(walkPath string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				fi, err := d.Info()
+				if err != nil {
+					return err
+				}
+
+				entry := findEntry{fsys: res.FS, path: walkPath, fi: fi}
+				ok, err := expr.eval(entry)
+				if err != nil {
+					return err
+				}
+				if ok {
+					return runFindAction(p.action, entry)
+				}
+				return nil
+			}))
+		}
+	}
+}
+
+func runFindAction(action string, e findEntry) error {
+	switch action {
+	case "hash":
+		if e.fi.IsDir() {
+			return nil
+		}
+		sum, err := hashFileContent(e.fsys, e.path, "sha256")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s  %s\n", sum, e.path)
+	case "stat":
+		fmt.Printf("Name: %v\n", e.fi.Name())
+		fmt.Printf("Size: %v\n", e.fi.Size())
+		fmt.Printf("IsDir: %v\n", e.fi.IsDir())
+		fmt.Printf("Mode: %s\n", e.fi.Mode())
+		fmt.Printf("Modified: %s\n", e.fi.ModTime())
+	case "cat":
+		if e.fi.IsDir() {
+			return nil
+		}
+		f, err := e.fsys.Open(e.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(os.Stdout, f)
+		return err
+	default:
+		fmt.Println(e.path)
+	}
+	return nil
+}
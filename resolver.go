@@ -0,0 +1,209 @@
+// Copyright (c) 2019-2020 Siemens AG
+// Copyright (c) 2019-2021 Jonas Plum
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package fscmd
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Resource is a single argument resolved to the fs.FS it lives in and its
+// path within that filesystem.
+type Resource struct {
+	FS   fs.FS
+	Name string
+}
+
+// MultiParseFunc resolves command line arguments to one Resource per
+// argument, allowing a single command invocation to operate across several,
+// independently backed filesystems (e.g. one argument pointing into a zip
+// archive and another into a raw disk image).
+type MultiParseFunc func(cmd *cobra.Command, args []string) ([]Resource, error)
+
+// AdaptParseFunc wraps a single-filesystem parseFunc (the original fscmd
+// parse signature) as a MultiParseFunc, so existing callers of FSCommand do
+// not need to change to keep working with the *Cmd constructors.
+func AdaptParseFunc(parse func(cmd *cobra.Command, args []string) (fs.FS, []string, error)) MultiParseFunc {
+	return func(cmd *cobra.Command, args []string) ([]Resource, error) {
+		fsys, names, err := parse(cmd, args)
+		if err != nil {
+			return nil, err
+		}
+		resources := make([]Resource, len(names))
+		for i, name := range names {
+			resources[i] = Resource{FS: fsys, Name: name}
+		}
+		return resources, nil
+	}
+}
+
+// adaptMultiToSingle is the inverse of AdaptParseFunc: it builds a
+// single-filesystem parseFunc from a MultiParseFunc, for the subcommands
+// (checksum, file, strings) that only ever operate on one fs.FS at a time.
+// It is an error for the resolved resources to span more than one
+// filesystem; commands that need to mix backends should use diff or find
+// instead.
+func adaptMultiToSingle(parse MultiParseFunc) func(cmd *cobra.Command, args []string) (fs.FS, []string, error) {
+	return func(cmd *cobra.Command, args []string) (fs.FS, []string, error) {
+		resources, err := parse(cmd, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(resources) == 0 {
+			return nil, nil, nil
+		}
+
+		fsys := resources[0].FS
+		names := make([]string, len(resources))
+		for i, res := range resources {
+			if !sameFS(res.FS, fsys) {
+				return nil, nil, fmt.Errorf("fscmd: this command does not support mixing multiple filesystems in one invocation; use diff or find instead")
+			}
+			names[i] = res.Name
+		}
+		return fsys, names, nil
+	}
+}
+
+// sameFS reports whether a and b are the same fs.FS. fs.FS implementations
+// are not required to be comparable (e.g. a map-backed filesystem), in which
+// case a == b would panic; sameFS treats that case as a match, since the
+// common case of a non-comparable fs.FS is a single backend reused across
+// every resource.
+func sameFS(a, b fs.FS) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = true
+		}
+	}()
+	return a == b
+}
+
+// Opener opens the filesystem backend addressed by a scheme URL (e.g.
+// "zip://evidence.zip!/foo") and returns the fs.FS together with the path to
+// use inside it (e.g. "foo").
+type Opener func(url string) (fs.FS, string, error)
+
+// FSResolver resolves URL-style arguments against a set of registered
+// filesystem backends, so a single command line can mix e.g. "zip://" and
+// "raw://" arguments. Arguments without a "scheme://" prefix fall back to a
+// single filesystem given at construction time.
+//
+// Backends opened for a given base URL (the part before "!/") are cached and
+// reused across arguments, so repeated references into the same archive or
+// image only open it once.
+type FSResolver struct {
+	fallback fs.FS
+	openers  map[string]Opener
+	opened   map[string]fs.FS
+}
+
+// NewFSResolver creates an FSResolver. Arguments without a registered scheme
+// are resolved directly against fallback.
+func NewFSResolver(fallback fs.FS) *FSResolver {
+	return &FSResolver{
+		fallback: fallback,
+		openers:  map[string]Opener{},
+		opened:   map[string]fs.FS{},
+	}
+}
+
+// Register adds a backend for scheme, e.g. Register("zip", zipOpener).
+func (r *FSResolver) Register(scheme string, opener Opener) {
+	r.openers[scheme] = opener
+}
+
+// Resolve implements MultiParseFunc.
+func (r *FSResolver) Resolve(_ *cobra.Command, args []string) ([]Resource, error) {
+	resources := make([]Resource, 0, len(args))
+	for _, arg := range args {
+		fsys, name, err := r.resolveOne(arg)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, Resource{FS: fsys, Name: name})
+	}
+	return resources, nil
+}
+
+func (r *FSResolver) resolveOne(url string) (fs.FS, string, error) {
+	scheme, ok := splitScheme(url)
+	if !ok {
+		return r.fallback, url, nil
+	}
+
+	opener, ok := r.openers[scheme]
+	if !ok {
+		return nil, "", fmt.Errorf("fscmd: no filesystem backend registered for scheme %q", scheme)
+	}
+
+	base, name := splitContainer(url)
+	if fsys, ok := r.opened[base]; ok {
+		return fsys, name, nil
+	}
+
+	fsys, name, err := opener(url)
+	if err != nil {
+		return nil, "", err
+	}
+	r.opened[base] = fsys
+	return fsys, name, nil
+}
+
+// splitScheme reports the "scheme" of a "scheme://..." URL.
+func splitScheme(url string) (scheme string, ok bool) {
+	i := strings.Index(url, "://")
+	if i < 0 {
+		return "", false
+	}
+	return url[:i], true
+}
+
+// ResolveDiff adapts an FSResolver into a Parse2Func, so DiffCmd can compare
+// two heterogeneous arguments (e.g. "zip://a.zip!/foo" against
+// "raw://disk.dd!/NTFS/Users") while still sharing the resolver's backend
+// cache with any other command run in the same process.
+func (r *FSResolver) ResolveDiff(cmd *cobra.Command, args []string) (fs.FS, string, fs.FS, string, error) {
+	if len(args) != 2 {
+		return nil, "", nil, "", fmt.Errorf("diff requires exactly two paths, got %d", len(args))
+	}
+	resources, err := r.Resolve(cmd, args)
+	if err != nil {
+		return nil, "", nil, "", err
+	}
+	return resources[0].FS, resources[0].Name, resources[1].FS, resources[1].Name, nil
+}
+
+// splitContainer splits a scheme URL into the part identifying its backing
+// container (e.g. the archive or image file, used as the opener cache key)
+// and the path requested inside it, using the "!/" separator convention
+// (e.g. "zip://evidence.zip!/foo" -> "zip://evidence.zip", "foo").
+func splitContainer(url string) (base, name string) {
+	if i := strings.Index(url, "!/"); i >= 0 {
+		return url[:i], url[i+2:]
+	}
+	return url, "."
+}
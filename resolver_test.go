@@ -0,0 +1,115 @@
+// Copyright (c) 2019-2020 Siemens AG
+// Copyright (c) 2019-2021 Jonas Plum
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package fscmd
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_FSResolver(t *testing.T) {
+	fallback := &fstest.MapFS{"foo": &fstest.MapFile{Data: []byte("foo")}}
+	zipFS := &fstest.MapFS{"bar": &fstest.MapFile{Data: []byte("bar")}}
+
+	opens := 0
+	resolver := NewFSResolver(fallback)
+	resolver.Register("zip", func(url string) (fs.FS, string, error) {
+		opens++
+		return zipFS, "bar", nil
+	})
+
+	resources, err := resolver.Resolve(nil, []string{"foo", "zip://evidence.zip!/bar", "zip://evidence.zip!/bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 3 {
+		t.Fatalf("Resolve() returned %d resources, want 3", len(resources))
+	}
+	if resources[0].FS != fallback || resources[0].Name != "foo" {
+		t.Errorf("Resolve()[0] = %+v, want fallback/foo", resources[0])
+	}
+	if resources[1].FS != zipFS || resources[1].Name != "bar" {
+		t.Errorf("Resolve()[1] = %+v, want zipFS/bar", resources[1])
+	}
+	if resources[2].FS != zipFS || resources[2].Name != "bar" {
+		t.Errorf("Resolve()[2] = %+v, want zipFS/bar", resources[2])
+	}
+	if opens != 1 {
+		t.Errorf("opener called %d times, want 1 (cached across overlapping args)", opens)
+	}
+}
+
+// Test_sameFS_noncomparable ensures sameFS never panics for a non-comparable
+// fs.FS implementation (e.g. a bare, non-pointer fstest.MapFS, which is a map
+// type), and that it treats such values as the same filesystem.
+func Test_sameFS_noncomparable(t *testing.T) {
+	a := fstest.MapFS{"foo": &fstest.MapFile{Data: []byte("foo")}}
+	b := fstest.MapFS{"bar": &fstest.MapFile{Data: []byte("bar")}}
+	if !sameFS(a, b) {
+		t.Error("sameFS() = false for non-comparable fs.FS values, want true")
+	}
+}
+
+func Test_FSResolver_unregistered_scheme(t *testing.T) {
+	resolver := NewFSResolver(&fstest.MapFS{})
+	if _, err := resolver.Resolve(nil, []string{"raw://disk.dd!/NTFS"}); err == nil {
+		t.Error("Resolve() with an unregistered scheme should return an error")
+	}
+}
+
+// Test_FSCommandWithResolver proves FSCommandWithResolver is a real,
+// reachable entry point: it wires a registered scheme opener into the root
+// command's cat subcommand and resolves a "mem://" argument alongside a
+// plain fallback argument in the same invocation.
+func Test_FSCommandWithResolver(t *testing.T) {
+	fallback := &fstest.MapFS{"foo": &fstest.MapFile{Data: []byte("foo")}}
+	memFS := &fstest.MapFS{"bar": &fstest.MapFile{Data: []byte("bar")}}
+
+	resolver := NewFSResolver(fallback)
+	resolver.Register("mem", func(url string) (fs.FS, string, error) {
+		return memFS, "bar", nil
+	})
+
+	rootCmd := FSCommandWithResolver(resolver)
+	gotData := stdout(func() {
+		rootCmd.SetArgs([]string{"cat", "foo", "mem://evidence!/bar"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if string(gotData) != "foobar" {
+		t.Errorf("fs cat foo mem://evidence!/bar = %q, want %q", gotData, "foobar")
+	}
+}
+
+func Test_AdaptParseFunc(t *testing.T) {
+	multi := AdaptParseFunc(defaultParse)
+	resources, err := multi(nil, []string{"foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || resources[0].FS != fs.FS(testFS) || resources[0].Name != "foo" {
+		t.Errorf("AdaptParseFunc() = %+v, want one resource for foo", resources)
+	}
+}